@@ -2,6 +2,8 @@ package insertutils
 
 import (
 	"flag"
+	"fmt"
+	"math"
 	"net/http"
 	"strings"
 	"sync"
@@ -20,6 +22,18 @@ import (
 var (
 	defaultMsgValue = flag.String("defaultMsgValue", "missing _msg field; see https://docs.victoriametrics.com/victorialogs/keyconcepts/#message-field",
 		"Default value for _msg field if the ingested log entry doesn't contain it; see https://docs.victoriametrics.com/victorialogs/keyconcepts/#message-field")
+
+	timestampGrace = flag.Duration("insert.timestampGrace", 0, "The maximum duration the ingested log entry timestamp can be in the past compared to the current time; "+
+		"entries with older timestamps are handled according to -insert.timestampOutOfWindowAction; zero disables the check; "+
+		"see https://docs.victoriametrics.com/victorialogs/data-ingestion/#http-parameters")
+	timestampDelay = flag.Duration("insert.timestampDelay", 0, "The maximum duration the ingested log entry timestamp can be in the future compared to the current time; "+
+		"entries with newer timestamps are handled according to -insert.timestampOutOfWindowAction; zero disables the check; "+
+		"see https://docs.victoriametrics.com/victorialogs/data-ingestion/#http-parameters")
+	timestampOutOfWindowAction = flag.String("insert.timestampOutOfWindowAction", "accept", "What to do with log entries whose timestamp falls outside "+
+		"[now-insert.timestampGrace ... now+insert.timestampDelay]; supported values are: accept, drop, clamp")
+
+	sinkQueueCapacity = flag.Int("insert.sinkQueueCapacity", 4, "The maximum number of pending row batches, which can be queued per LogSink "+
+		"before new batches are dropped; see https://docs.victoriametrics.com/victorialogs/data-ingestion/#http-parameters")
 )
 
 // CommonParams contains common HTTP parameters used by log ingestion APIs.
@@ -32,6 +46,19 @@ type CommonParams struct {
 	StreamFields []string
 	IgnoreFields []string
 
+	// TimestampGrace and TimestampDelay define the acceptable window around the current time
+	// for the ingested log entry timestamp; see -insert.timestampGrace and -insert.timestampDelay.
+	TimestampGrace time.Duration
+	TimestampDelay time.Duration
+
+	// RateLimitAction defines what to do with rows exceeding the configured rate limits;
+	// see -insert.rateLimitAction.
+	RateLimitAction string
+
+	// Transformers is the ordered chain of FieldTransformer applied to every row before
+	// it is added to storage; see buildTransformersFromRequest.
+	Transformers []FieldTransformer
+
 	Debug           bool
 	DebugRequestURI string
 	DebugRemoteAddr string
@@ -78,6 +105,36 @@ func GetCommonParams(r *http.Request) (*CommonParams, error) {
 		}
 	}
 
+	// Extract timestamp grace and delay windows from _time_grace / _time_delay query args or headers
+	tsGrace := *timestampGrace
+	if tg := r.FormValue("_time_grace"); tg != "" {
+		d, err := time.ParseDuration(tg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse _time_grace=%q: %w", tg, err)
+		}
+		tsGrace = d
+	} else if tg := r.Header.Get("VL-Time-Grace"); tg != "" {
+		d, err := time.ParseDuration(tg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse VL-Time-Grace header=%q: %w", tg, err)
+		}
+		tsGrace = d
+	}
+	tsDelay := *timestampDelay
+	if td := r.FormValue("_time_delay"); td != "" {
+		d, err := time.ParseDuration(td)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse _time_delay=%q: %w", td, err)
+		}
+		tsDelay = d
+	} else if td := r.Header.Get("VL-Time-Delay"); td != "" {
+		d, err := time.ParseDuration(td)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse VL-Time-Delay header=%q: %w", td, err)
+		}
+		tsDelay = d
+	}
+
 	debug := httputils.GetBool(r, "debug")
 	if !debug {
 		if dh := r.Header.Get("VL-Debug"); len(dh) > 0 {
@@ -102,6 +159,10 @@ func GetCommonParams(r *http.Request) (*CommonParams, error) {
 		MsgFields:       msgFields,
 		StreamFields:    streamFields,
 		IgnoreFields:    ignoreFields,
+		TimestampGrace:  tsGrace,
+		TimestampDelay:  tsDelay,
+		RateLimitAction: *rateLimitAction,
+		Transformers:    buildTransformersFromRequest(r),
 		Debug:           debug,
 		DebugRequestURI: debugRequestURI,
 		DebugRemoteAddr: debugRemoteAddr,
@@ -119,6 +180,10 @@ func GetCommonParamsForSyslog(tenantID logstorage.TenantID) *CommonParams {
 		MsgFields: []string{
 			"message",
 		},
+		TimestampGrace:  *timestampGrace,
+		TimestampDelay:  *timestampDelay,
+		RateLimitAction: *rateLimitAction,
+		Transformers:    []FieldTransformer{getRedactTransformer()},
 		StreamFields: []string{
 			"hostname",
 			"app_name",
@@ -140,6 +205,90 @@ type LogMessageProcessor interface {
 	MustClose()
 }
 
+// LogSink is a destination rows ingested via LogMessageProcessor can be mirrored to,
+// in addition to the built-in vlstorage.MustAddRows path.
+//
+// Implementations must not retain lr after AddRows returns, since the caller re-uses it.
+type LogSink interface {
+	// Name uniquely identifies the sink; it is used as the sink label
+	// on vl_rows_dropped_total{sink="...",reason="queue_full"}.
+	Name() string
+
+	// AddRows sends all the rows accumulated in lr to the sink.
+	AddRows(lr *logstorage.LogRows) error
+}
+
+// sinkState holds the per-LogSink accumulator and bounded delivery queue.
+//
+// Rows are accumulated independently of the primary vlstorage flush, so a slow
+// or unavailable sink cannot block ingestion into local storage.
+type sinkState struct {
+	sink LogSink
+
+	// lr accumulates rows between flushes; it is swapped out for a fresh one
+	// once handed off to the queue.
+	lr *logstorage.LogRows
+
+	queue   chan *logstorage.LogRows
+	dropped *metrics.Counter
+
+	wg     sync.WaitGroup
+	stopCh chan struct{}
+}
+
+func newSinkState(sink LogSink, cp *CommonParams) *sinkState {
+	ss := &sinkState{
+		sink:    sink,
+		lr:      logstorage.GetLogRows(cp.StreamFields, cp.IgnoreFields),
+		queue:   make(chan *logstorage.LogRows, *sinkQueueCapacity),
+		dropped: metrics.GetOrCreateCounter(fmt.Sprintf(`vl_rows_dropped_total{sink=%q,reason="queue_full"}`, sink.Name())),
+		stopCh:  make(chan struct{}),
+	}
+	ss.wg.Add(1)
+	go ss.run()
+	return ss
+}
+
+func (ss *sinkState) run() {
+	defer ss.wg.Done()
+	for {
+		select {
+		case lr := <-ss.queue:
+			ss.process(lr)
+		case <-ss.stopCh:
+			// Drain the remaining queue before exiting.
+			for {
+				select {
+				case lr := <-ss.queue:
+					ss.process(lr)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (ss *sinkState) process(lr *logstorage.LogRows) {
+	if err := ss.sink.AddRows(lr); err != nil {
+		logger.Warnf("cannot send rows to sink: %s", err)
+	}
+	logstorage.PutLogRows(lr)
+}
+
+// flushLocked hands off the currently accumulated rows to the sink's queue and
+// starts accumulating into a fresh batch. It must be called under the owning
+// logMessageProcessor's mu.
+func (ss *sinkState) flushLocked(cp *CommonParams) {
+	select {
+	case ss.queue <- ss.lr:
+		ss.lr = logstorage.GetLogRows(cp.StreamFields, cp.IgnoreFields)
+	default:
+		ss.dropped.Inc()
+		ss.lr.ResetKeepSettings()
+	}
+}
+
 type logMessageProcessor struct {
 	mu            sync.Mutex
 	wg            sync.WaitGroup
@@ -148,8 +297,9 @@ type logMessageProcessor struct {
 
 	tmpFields []logstorage.Field
 
-	cp *CommonParams
-	lr *logstorage.LogRows
+	cp    *CommonParams
+	lr    *logstorage.LogRows
+	sinks []*sinkState
 }
 
 func (lmp *logMessageProcessor) initPeriodicFlush() {
@@ -190,6 +340,23 @@ func (lmp *logMessageProcessor) AddRow(timestamp int64, fields []logstorage.Fiel
 		return
 	}
 
+	if !checkRateLimit(lmp.cp.TenantID, fields, lmp.cp.RateLimitAction) {
+		rf := logstorage.RowFormatter(fields)
+		logger.Warnf("dropping log line for accountID=%d, projectID=%d because of rate limiting; %s", lmp.cp.TenantID.AccountID, lmp.cp.TenantID.ProjectID, rf)
+		return
+	}
+
+	if lmp.cp.TimestampGrace > 0 || lmp.cp.TimestampDelay > 0 {
+		var dropped bool
+		timestamp, dropped = checkTimestampWindow(timestamp, lmp.cp.TimestampGrace, lmp.cp.TimestampDelay)
+		if dropped {
+			rf := logstorage.RowFormatter(fields)
+			logger.Warnf("dropping log line with out-of-window timestamp=%d; it doesn't fit [now-insert.timestampGrace ... now+insert.timestampDelay]; %s", timestamp, rf)
+			rowsDroppedTotalTimestampOutOfWindow.Inc()
+			return
+		}
+	}
+
 	if *defaultMsgValue != "" && !hasMsgField(fields) {
 		// The log entry doesn't contain mandatory _msg field. Add _msg field with default value then
 		// according to https://docs.victoriametrics.com/victorialogs/keyconcepts/#message-field .
@@ -201,10 +368,20 @@ func (lmp *logMessageProcessor) AddRow(timestamp int64, fields []logstorage.Fiel
 		fields = lmp.tmpFields
 	}
 
+	for _, t := range lmp.cp.Transformers {
+		fields = t.Transform(fields)
+	}
+
 	lmp.lr.MustAdd(lmp.cp.TenantID, timestamp, fields)
+	for _, ss := range lmp.sinks {
+		ss.lr.MustAdd(lmp.cp.TenantID, timestamp, fields)
+	}
 	if lmp.cp.Debug {
 		s := lmp.lr.GetRowString(0)
 		lmp.lr.ResetKeepSettings()
+		for _, ss := range lmp.sinks {
+			ss.lr.ResetKeepSettings()
+		}
 		logger.Infof("remoteAddr=%s; requestURI=%s; ignoring log entry because of `debug` arg: %s", lmp.cp.DebugRemoteAddr, lmp.cp.DebugRequestURI, s)
 		rowsDroppedTotalDebug.Inc()
 		return
@@ -214,6 +391,37 @@ func (lmp *logMessageProcessor) AddRow(timestamp int64, fields []logstorage.Fiel
 	}
 }
 
+// checkTimestampWindow verifies that timestamp falls into [now-grace ... now+delay].
+//
+// It returns the (possibly clamped) timestamp to use and whether the row must be dropped,
+// according to -insert.timestampOutOfWindowAction.
+func checkTimestampWindow(timestamp int64, grace, delay time.Duration) (int64, bool) {
+	now := time.Now().UnixNano()
+	minAllowed := int64(math.MinInt64)
+	if grace > 0 {
+		minAllowed = now - grace.Nanoseconds()
+	}
+	maxAllowed := int64(math.MaxInt64)
+	if delay > 0 {
+		maxAllowed = now + delay.Nanoseconds()
+	}
+	if timestamp >= minAllowed && timestamp <= maxAllowed {
+		return timestamp, false
+	}
+	switch *timestampOutOfWindowAction {
+	case "drop":
+		return timestamp, true
+	case "clamp":
+		if timestamp < minAllowed {
+			return minAllowed, false
+		}
+		return maxAllowed, false
+	default:
+		// accept - leave the timestamp as is
+		return timestamp, false
+	}
+}
+
 func hasMsgField(fields []logstorage.Field) bool {
 	for _, f := range fields {
 		if f.Name == "_msg" {
@@ -228,9 +436,14 @@ func (lmp *logMessageProcessor) flushLocked() {
 	lmp.lastFlushTime = time.Now()
 	vlstorage.MustAddRows(lmp.lr)
 	lmp.lr.ResetKeepSettings()
+
+	for _, ss := range lmp.sinks {
+		ss.flushLocked(lmp.cp)
+	}
 }
 
-// MustClose flushes the remaining data to the underlying storage and closes lmp.
+// MustClose flushes the remaining data to the underlying storage and to every
+// configured LogSink, then closes lmp.
 func (lmp *logMessageProcessor) MustClose() {
 	close(lmp.stopCh)
 	lmp.wg.Wait()
@@ -238,12 +451,20 @@ func (lmp *logMessageProcessor) MustClose() {
 	lmp.flushLocked()
 	logstorage.PutLogRows(lmp.lr)
 	lmp.lr = nil
+
+	for _, ss := range lmp.sinks {
+		close(ss.stopCh)
+		ss.wg.Wait()
+	}
 }
 
 // NewLogMessageProcessor returns new LogMessageProcessor for the given cp.
 //
+// Besides the built-in vlstorage path, rows are additionally mirrored into every
+// sink in extraSinks; a slow or unavailable sink never blocks ingestion into local storage.
+//
 // MustClose() must be called on the returned LogMessageProcessor when it is no longer needed.
-func (cp *CommonParams) NewLogMessageProcessor() LogMessageProcessor {
+func (cp *CommonParams) NewLogMessageProcessor(extraSinks ...LogSink) LogMessageProcessor {
 	lr := logstorage.GetLogRows(cp.StreamFields, cp.IgnoreFields)
 	lmp := &logMessageProcessor{
 		cp: cp,
@@ -251,12 +472,16 @@ func (cp *CommonParams) NewLogMessageProcessor() LogMessageProcessor {
 
 		stopCh: make(chan struct{}),
 	}
+	for _, sink := range extraSinks {
+		lmp.sinks = append(lmp.sinks, newSinkState(sink, cp))
+	}
 	lmp.initPeriodicFlush()
 
 	return lmp
 }
 
 var (
-	rowsDroppedTotalDebug         = metrics.NewCounter(`vl_rows_dropped_total{reason="debug"}`)
-	rowsDroppedTotalTooManyFields = metrics.NewCounter(`vl_rows_dropped_total{reason="too_many_fields"}`)
+	rowsDroppedTotalDebug                = metrics.NewCounter(`vl_rows_dropped_total{reason="debug"}`)
+	rowsDroppedTotalTooManyFields        = metrics.NewCounter(`vl_rows_dropped_total{reason="too_many_fields"}`)
+	rowsDroppedTotalTimestampOutOfWindow = metrics.NewCounter(`vl_rows_dropped_total{reason="timestamp_out_of_window"}`)
 )
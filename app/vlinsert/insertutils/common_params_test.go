@@ -0,0 +1,58 @@
+package insertutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckTimestampWindow(t *testing.T) {
+	defer func(action string) {
+		*timestampOutOfWindowAction = action
+	}(*timestampOutOfWindowAction)
+
+	const grace = 10 * time.Second
+	const delay = 5 * time.Second
+
+	// maxSkew bounds the difference between the time.Now() captured by the test
+	// and the one checkTimestampWindow reads internally, so clamp assertions don't flake.
+	const maxSkew = int64(time.Second)
+
+	f := func(action string, timestamp, wantTimestamp int64, wantDropped bool) {
+		t.Helper()
+
+		*timestampOutOfWindowAction = action
+		gotTimestamp, gotDropped := checkTimestampWindow(timestamp, grace, delay)
+		if gotDropped != wantDropped {
+			t.Fatalf("unexpected dropped for action=%q, timestamp=%d; got %v; want %v", action, timestamp, gotDropped, wantDropped)
+		}
+		if !gotDropped {
+			if diff := gotTimestamp - wantTimestamp; diff < -maxSkew || diff > maxSkew {
+				t.Fatalf("unexpected timestamp for action=%q, timestamp=%d; got %d; want %d (+/- %d)", action, timestamp, gotTimestamp, wantTimestamp, maxSkew)
+			}
+		}
+	}
+
+	now := time.Now().UnixNano()
+	inWindow := now
+	tooOld := now - grace.Nanoseconds() - time.Second.Nanoseconds()
+	tooNew := now + delay.Nanoseconds() + time.Second.Nanoseconds()
+
+	// Timestamps inside the window are left untouched regardless of the configured action.
+	f("drop", inWindow, inWindow, false)
+	f("clamp", inWindow, inWindow, false)
+	f("accept", inWindow, inWindow, false)
+
+	// action=drop discards out-of-window timestamps in either direction.
+	f("drop", tooOld, 0, true)
+	f("drop", tooNew, 0, true)
+
+	// action=clamp pulls out-of-window timestamps back to the nearest window edge.
+	minAllowed := now - grace.Nanoseconds()
+	maxAllowed := now + delay.Nanoseconds()
+	f("clamp", tooOld, minAllowed, false)
+	f("clamp", tooNew, maxAllowed, false)
+
+	// action=accept (and any other/default value) leaves out-of-window timestamps as is.
+	f("accept", tooOld, tooOld, false)
+	f("accept", tooNew, tooNew, false)
+}
@@ -0,0 +1,84 @@
+package insertutils
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"cloud.google.com/go/logging"
+	"google.golang.org/api/option"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+var (
+	gcpSinkProjectID = flag.String("insert.gcp.projectID", "", "GCP project id to use for the optional Google Cloud Logging sink; see -insert.gcp.logID")
+	gcpSinkLogID     = flag.String("insert.gcp.logID", "victorialogs", "Log id to use when mirroring ingested rows into Google Cloud Logging; see -insert.gcp.projectID")
+	gcpSinkCredsFile = flag.String("insert.gcp.credentialsFile", "", "Optional path to a GCP service account credentials JSON file to use for the Google Cloud Logging sink; "+
+		"if empty, the usual Application Default Credentials lookup is used (GOOGLE_APPLICATION_CREDENTIALS env var, metadata server, etc.)")
+)
+
+// GCPLogSink is a LogSink, which mirrors ingested rows into Google Cloud Logging.
+//
+// It is enabled by setting -insert.gcp.projectID. Entries are batched and written
+// asynchronously by the underlying Cloud Logging client.
+type GCPLogSink struct {
+	client *logging.Client
+	lg     *logging.Logger
+}
+
+// NewGCPLogSinkFromFlags returns a new GCPLogSink built from -insert.gcp.* flags,
+// or nil if -insert.gcp.projectID isn't set.
+//
+// MustClose must be called on the returned sink when it is no longer needed.
+func NewGCPLogSinkFromFlags() (*GCPLogSink, error) {
+	if *gcpSinkProjectID == "" {
+		return nil, nil
+	}
+	return NewGCPLogSink(*gcpSinkProjectID, *gcpSinkLogID, *gcpSinkCredsFile)
+}
+
+// NewGCPLogSink returns a new GCPLogSink, which ships rows into the given GCP projectID under logID.
+//
+// If credentialsFile is empty, the usual Application Default Credentials lookup is used.
+func NewGCPLogSink(projectID, logID, credentialsFile string) (*GCPLogSink, error) {
+	var opts []option.ClientOption
+	if credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsFile))
+	}
+	client, err := logging.NewClient(context.Background(), projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Google Cloud Logging client for projectID=%q: %w", projectID, err)
+	}
+	return &GCPLogSink{
+		client: client,
+		lg:     client.Logger(logID),
+	}, nil
+}
+
+// Name returns the sink name used in metric labels.
+func (gs *GCPLogSink) Name() string {
+	return "gcp"
+}
+
+// AddRows sends all the rows in lr to Google Cloud Logging.
+//
+// The entries are queued on the underlying client, which batches and flushes
+// entries.write calls in the background.
+func (gs *GCPLogSink) AddRows(lr *logstorage.LogRows) error {
+	n := lr.Len()
+	for i := 0; i < n; i++ {
+		gs.lg.Log(logging.Entry{
+			Payload: lr.GetRowString(i),
+		})
+	}
+	return nil
+}
+
+// MustClose flushes the buffered entries and closes the underlying client.
+func (gs *GCPLogSink) MustClose() {
+	if err := gs.client.Close(); err != nil {
+		logger.Warnf("cannot close Google Cloud Logging client: %s", err)
+	}
+}
@@ -0,0 +1,225 @@
+package insertutils
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"golang.org/x/time/rate"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+var (
+	maxRowsPerSecondPerTenant = flag.Float64("insert.maxRowsPerSecondPerTenant", 0, "The maximum number of rows per second the given tenant can ingest; "+
+		"rows exceeding this rate are handled according to -insert.rateLimitAction; zero disables the per-tenant rows limit")
+	maxBytesPerSecondPerTenant = flag.Float64("insert.maxBytesPerSecondPerTenant", 0, "The maximum number of bytes per second the given tenant can ingest; "+
+		"rows exceeding this rate are handled according to -insert.rateLimitAction; zero disables the per-tenant bytes limit")
+	maxRowsPerSecond = flag.Float64("insert.maxRowsPerSecond", 0, "The maximum number of rows per second, which can be ingested across all tenants; "+
+		"rows exceeding this rate are handled according to -insert.rateLimitAction; zero disables the global rows limit")
+	maxBytesPerSecond = flag.Float64("insert.maxBytesPerSecond", 0, "The maximum number of bytes per second, which can be ingested across all tenants; "+
+		"rows exceeding this rate are handled according to -insert.rateLimitAction; zero disables the global bytes limit")
+	rateLimitAction = flag.String("insert.rateLimitAction", "drop", "What to do with rows exceeding -insert.maxRowsPerSecondPerTenant, -insert.maxBytesPerSecondPerTenant, "+
+		"-insert.maxRowsPerSecond or -insert.maxBytesPerSecond; supported values are: drop, block")
+	rateLimitWaitTimeout = flag.Duration("insert.rateLimitWaitTimeout", 5*time.Second, "The maximum duration to wait for a free rate limit slot "+
+		"when -insert.rateLimitAction=block before dropping the row")
+)
+
+// tenantLimiter holds the per-tenant token buckets and acceptance stats
+// used for enforcing -insert.maxRowsPerSecondPerTenant and -insert.maxBytesPerSecondPerTenant.
+type tenantLimiter struct {
+	rows  *rate.Limiter
+	bytes *rate.Limiter
+
+	accepted atomic.Uint64
+	rejected atomic.Uint64
+
+	droppedTotal *metrics.Counter
+}
+
+var (
+	tenantLimitersMu sync.Mutex
+	tenantLimiters   = make(map[logstorage.TenantID]*tenantLimiter)
+
+	// globalLimitersOnce guards the one-time construction of globalRowsLimiter/globalBytesLimiter
+	// from -insert.maxRowsPerSecond/-insert.maxBytesPerSecond below.
+	//
+	// Tests that flip those flags must call resetGlobalLimitersForTest first: since this is a
+	// process-wide sync.Once, whichever test calls getGlobalLimiters() first "wins" the flag
+	// values for the rest of the test binary otherwise.
+	globalLimitersOnce sync.Once
+	globalRowsLimiter  *rate.Limiter
+	globalBytesLimiter *rate.Limiter
+)
+
+func getGlobalLimiters() (*rate.Limiter, *rate.Limiter) {
+	globalLimitersOnce.Do(func() {
+		globalRowsLimiter = newLimiter(*maxRowsPerSecond, 1)
+		globalBytesLimiter = newLimiter(*maxBytesPerSecond, bytesLimiterMinBurst)
+	})
+	return globalRowsLimiter, globalBytesLimiter
+}
+
+// resetGlobalLimitersForTest clears the cached global limiters, so the next call to
+// getGlobalLimiters() picks up the current value of -insert.maxRowsPerSecond/-insert.maxBytesPerSecond.
+//
+// It must only be called from tests in this package, never from production code.
+func resetGlobalLimitersForTest() {
+	globalLimitersOnce = sync.Once{}
+	globalRowsLimiter = nil
+	globalBytesLimiter = nil
+}
+
+// bytesLimiterMinBurst is the minimum burst size for byte-rate limiters, so that
+// a single large row isn't rejected outright just because its size exceeds the per-second rate.
+const bytesLimiterMinBurst = 1 << 20
+
+func newLimiter(ratePerSecond float64, minBurst int) *rate.Limiter {
+	if ratePerSecond <= 0 {
+		return nil
+	}
+	burst := int(ratePerSecond)
+	if burst < minBurst {
+		burst = minBurst
+	}
+	return rate.NewLimiter(rate.Limit(ratePerSecond), burst)
+}
+
+func getTenantLimiter(tenantID logstorage.TenantID) *tenantLimiter {
+	tenantLimitersMu.Lock()
+	defer tenantLimitersMu.Unlock()
+
+	tl := tenantLimiters[tenantID]
+	if tl != nil {
+		return tl
+	}
+
+	tl = &tenantLimiter{
+		rows:  newLimiter(*maxRowsPerSecondPerTenant, 1),
+		bytes: newLimiter(*maxBytesPerSecondPerTenant, bytesLimiterMinBurst),
+	}
+	tenantLimiters[tenantID] = tl
+
+	tenantLabel := fmt.Sprintf("%d:%d", tenantID.AccountID, tenantID.ProjectID)
+	tl.droppedTotal = metrics.GetOrCreateCounter(fmt.Sprintf(`vl_rows_dropped_total{reason="rate_limited",tenant=%q}`, tenantLabel))
+	metrics.GetOrCreateGauge(fmt.Sprintf(`vl_insert_rows_rate_limit{tenant=%q}`, tenantLabel), func() float64 {
+		return *maxRowsPerSecondPerTenant
+	})
+	metrics.GetOrCreateGauge(fmt.Sprintf(`vl_insert_rows_rejection_ratio{tenant=%q}`, tenantLabel), func() float64 {
+		accepted := tl.accepted.Load()
+		rejected := tl.rejected.Load()
+		if accepted+rejected == 0 {
+			return 0
+		}
+		return float64(rejected) / float64(accepted+rejected)
+	})
+
+	return tl
+}
+
+// fieldsSizeBytes estimates the on-wire size of fields for the purposes of byte-rate limiting.
+func fieldsSizeBytes(fields []logstorage.Field) int {
+	n := 0
+	for _, f := range fields {
+		n += len(f.Name) + len(f.Value)
+	}
+	return n
+}
+
+// checkRateLimit applies the configured per-tenant and global rate limits to a single row.
+//
+// It returns true if the row is allowed to proceed, and false if it must be dropped
+// because neither the immediate token reservation succeeded nor action=="block" managed to acquire
+// a free slot within -insert.rateLimitWaitTimeout.
+func checkRateLimit(tenantID logstorage.TenantID, fields []logstorage.Field, action string) bool {
+	tl := getTenantLimiter(tenantID)
+	globalRows, globalBytes := getGlobalLimiters()
+
+	if tl.rows == nil && tl.bytes == nil && globalRows == nil && globalBytes == nil {
+		return true
+	}
+
+	size := fieldsSizeBytes(fields)
+	now := time.Now()
+
+	// allow reserves tokens from every configured limiter, short-circuiting and
+	// cancelling any reservations already made as soon as one limiter is empty, so a
+	// rejected row never burns tokens from the other (otherwise-passing) limiters.
+	allow := func() bool {
+		var reservations []*rate.Reservation
+		cancelAll := func() {
+			for _, rsv := range reservations {
+				rsv.CancelAt(now)
+			}
+		}
+
+		for _, lim := range []struct {
+			l *rate.Limiter
+			n int
+		}{
+			{tl.rows, 1},
+			{tl.bytes, size},
+			{globalRows, 1},
+			{globalBytes, size},
+		} {
+			if lim.l == nil {
+				continue
+			}
+			rsv := lim.l.ReserveN(now, lim.n)
+			if !rsv.OK() || rsv.DelayFrom(now) > 0 {
+				if rsv.OK() {
+					rsv.CancelAt(now)
+				}
+				cancelAll()
+				return false
+			}
+			reservations = append(reservations, rsv)
+		}
+		return true
+	}
+
+	if allow() {
+		tl.accepted.Add(1)
+		return true
+	}
+
+	if action != "block" {
+		tl.rejected.Add(1)
+		tl.droppedTotal.Inc()
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *rateLimitWaitTimeout)
+	defer cancel()
+
+	waiters := make([]*rate.Limiter, 0, 4)
+	for _, l := range []*rate.Limiter{tl.rows, globalRows} {
+		if l != nil {
+			waiters = append(waiters, l)
+		}
+	}
+	for _, l := range waiters {
+		if err := l.WaitN(ctx, 1); err != nil {
+			tl.rejected.Add(1)
+			tl.droppedTotal.Inc()
+			return false
+		}
+	}
+	for _, l := range []*rate.Limiter{tl.bytes, globalBytes} {
+		if l == nil {
+			continue
+		}
+		if err := l.WaitN(ctx, size); err != nil {
+			tl.rejected.Add(1)
+			tl.droppedTotal.Inc()
+			return false
+		}
+	}
+
+	tl.accepted.Add(1)
+	return true
+}
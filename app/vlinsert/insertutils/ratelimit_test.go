@@ -0,0 +1,60 @@
+package insertutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestFieldsSizeBytes(t *testing.T) {
+	fields := []logstorage.Field{
+		{Name: "foo", Value: "bar"},
+		{Name: "_msg", Value: "hello world"},
+	}
+	if n := fieldsSizeBytes(fields); n != 3+3+4+11 {
+		t.Fatalf("unexpected size; got %d; want %d", n, 3+3+4+11)
+	}
+}
+
+// TestCheckRateLimitDoesNotLeakTokensAcrossLimiters makes sure a row rejected by one
+// limiter (here the global rows limiter) doesn't silently consume tokens from another,
+// otherwise-passing limiter (here the per-tenant rows limiter). Before the fix, the
+// per-tenant limiter's AllowN call succeeded and consumed a token even on rows that
+// were ultimately rejected because of the global limiter, eroding the tenant's budget.
+func TestCheckRateLimitDoesNotLeakTokensAcrossLimiters(t *testing.T) {
+	*maxRowsPerSecondPerTenant = 2
+	*maxBytesPerSecondPerTenant = 0
+	*maxRowsPerSecond = 1
+	*maxBytesPerSecond = 0
+	resetGlobalLimitersForTest()
+
+	tenantID := logstorage.TenantID{AccountID: 1, ProjectID: 1}
+	fields := []logstorage.Field{{Name: "_msg", Value: "hello"}}
+
+	// Consumes the single global token and one of the two tenant tokens; both
+	// limiters have capacity, so the row is accepted.
+	if !checkRateLimit(tenantID, fields, "drop") {
+		t.Fatalf("expected the first row to be accepted")
+	}
+
+	// The global limiter is now empty, so these rows must be rejected regardless
+	// of the per-tenant limiter's state.
+	if checkRateLimit(tenantID, fields, "drop") {
+		t.Fatalf("expected the second row to be rejected by the exhausted global limiter")
+	}
+
+	// The per-tenant limiter must still hold the spare token the rejected row reserved
+	// and then returned. If that row had leaked a token, the limiter would be empty here.
+	if !getTenantLimiter(tenantID).rows.AllowN(time.Now(), 1) {
+		t.Fatalf("the per-tenant limiter's budget was eroded by a row rejected by the global limiter")
+	}
+
+	// Give the global limiter (1 row/second) time to refill its single token.
+	time.Sleep(1100 * time.Millisecond)
+
+	// Both limiters have refilled by now, so the row is accepted again.
+	if !checkRateLimit(tenantID, fields, "drop") {
+		t.Fatalf("expected the third row to be accepted once the global limiter has recovered")
+	}
+}
@@ -0,0 +1,299 @@
+package insertutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// FieldTransformer transforms the fields of a single row before it is added to storage.
+//
+// Transform may mutate and return fields in place, or return a different slice entirely,
+// e.g. when dropping or adding fields.
+type FieldTransformer interface {
+	Transform(fields []logstorage.Field) []logstorage.Field
+}
+
+var redactPatternsFlag repeatedFlag
+
+func init() {
+	flag.Var(&redactPatternsFlag, "insert.redactPattern", "Redaction rule in the form fieldName:regexp; matches of regexp inside the named field's value "+
+		"are replaced with [REDACTED]; can be specified multiple times")
+}
+
+// repeatedFlag is a flag.Value, which accumulates every occurrence of the flag on the command line.
+type repeatedFlag struct {
+	values []string
+}
+
+func (f *repeatedFlag) String() string {
+	return strings.Join(f.values, ",")
+}
+
+func (f *repeatedFlag) Set(s string) error {
+	f.values = append(f.values, s)
+	return nil
+}
+
+// buildTransformersFromRequest returns the per-request FieldTransformer chain configured
+// via the _rename_fields/_drop_fields/_hash_fields/_extract_json_fields query args and their
+// VL-Rename-Fields/VL-Drop-Fields/VL-Hash-Fields/VL-Extract-JSON-Fields header equivalents,
+// in addition to the globally-configured -insert.redactPattern redaction.
+func buildTransformersFromRequest(r *http.Request) []FieldTransformer {
+	transformers := make([]FieldTransformer, 0, 4)
+
+	if jt := newJSONExtractTransformer(getParam(r, "_extract_json_fields", "VL-Extract-JSON-Fields")); jt != nil {
+		transformers = append(transformers, jt)
+	}
+	transformers = append(transformers, getRedactTransformer())
+	if ht := newHashTransformer(getParam(r, "_hash_fields", "VL-Hash-Fields")); ht != nil {
+		transformers = append(transformers, ht)
+	}
+	if rt := newRenameDropTransformer(getParam(r, "_rename_fields", "VL-Rename-Fields"), getParam(r, "_drop_fields", "VL-Drop-Fields")); rt != nil {
+		transformers = append(transformers, rt)
+	}
+
+	return transformers
+}
+
+func getParam(r *http.Request, queryArg, header string) string {
+	if v := r.FormValue(queryArg); v != "" {
+		return v
+	}
+	return r.Header.Get(header)
+}
+
+// redactTransformer replaces regexp matches inside the configured fields with [REDACTED];
+// it is configured globally via -insert.redactPattern.
+type redactTransformer struct {
+	rules []redactRule
+}
+
+type redactRule struct {
+	field string
+	re    *regexp.Regexp
+}
+
+var (
+	redactTransformerOnce sync.Once
+	redactTransformerInst *redactTransformer
+)
+
+func getRedactTransformer() *redactTransformer {
+	redactTransformerOnce.Do(func() {
+		rt := &redactTransformer{}
+		for _, spec := range redactPatternsFlag.values {
+			name, pattern, ok := strings.Cut(spec, ":")
+			if !ok {
+				logger.Warnf("skipping malformed -insert.redactPattern=%q; expected format is fieldName:regexp", spec)
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				logger.Warnf("skipping malformed -insert.redactPattern=%q: cannot compile regexp: %s", spec, err)
+				continue
+			}
+			rt.rules = append(rt.rules, redactRule{field: name, re: re})
+		}
+		redactTransformerInst = rt
+	})
+	return redactTransformerInst
+}
+
+func (rt *redactTransformer) Transform(fields []logstorage.Field) []logstorage.Field {
+	for i := range fields {
+		for _, rule := range rt.rules {
+			if fields[i].Name != rule.field {
+				continue
+			}
+			if s := rule.re.ReplaceAllString(fields[i].Value, "[REDACTED]"); s != fields[i].Value {
+				fields[i].Value = s
+				fieldsRedactedTotal.Inc()
+			}
+		}
+	}
+	return fields
+}
+
+// renameDropTransformer renames and drops fields according to per-request
+// _rename_fields / _drop_fields parameters (or their VL-Rename-Fields / VL-Drop-Fields
+// header equivalents), independently of the ignore_fields query arg / VL-Ignore-Fields header.
+type renameDropTransformer struct {
+	rename map[string]string
+	drop   map[string]struct{}
+}
+
+func newRenameDropTransformer(renameSpec, dropSpec string) *renameDropTransformer {
+	if renameSpec == "" && dropSpec == "" {
+		return nil
+	}
+	rt := &renameDropTransformer{}
+	if renameSpec != "" {
+		rt.rename = make(map[string]string)
+		for _, pair := range strings.Split(renameSpec, ",") {
+			oldName, newName, ok := strings.Cut(pair, ":")
+			if !ok {
+				logger.Warnf("skipping malformed rename rule %q in _rename_fields; expected format is oldName:newName", pair)
+				continue
+			}
+			rt.rename[oldName] = newName
+		}
+	}
+	if dropSpec != "" {
+		rt.drop = make(map[string]struct{})
+		for _, name := range strings.Split(dropSpec, ",") {
+			rt.drop[name] = struct{}{}
+		}
+	}
+	return rt
+}
+
+func (rt *renameDropTransformer) Transform(fields []logstorage.Field) []logstorage.Field {
+	dst := fields[:0]
+	for _, f := range fields {
+		if _, ok := rt.drop[f.Name]; ok {
+			fieldsDroppedTotal.Inc()
+			continue
+		}
+		if newName, ok := rt.rename[f.Name]; ok {
+			f.Name = newName
+		}
+		dst = append(dst, f)
+	}
+	return dst
+}
+
+// hashTransformer replaces the value of the configured fields with a truncated SHA-256
+// hash, for pseudonymization; it is configured per-request via _hash_fields / VL-Hash-Fields.
+type hashTransformer struct {
+	fields map[string]struct{}
+}
+
+// hashValueLen is the number of hex characters kept from the SHA-256 digest.
+const hashValueLen = 16
+
+func newHashTransformer(spec string) *hashTransformer {
+	if spec == "" {
+		return nil
+	}
+	ht := &hashTransformer{
+		fields: make(map[string]struct{}),
+	}
+	for _, name := range strings.Split(spec, ",") {
+		ht.fields[name] = struct{}{}
+	}
+	return ht
+}
+
+func (ht *hashTransformer) Transform(fields []logstorage.Field) []logstorage.Field {
+	for i := range fields {
+		if _, ok := ht.fields[fields[i].Name]; !ok {
+			continue
+		}
+		sum := sha256.Sum256([]byte(fields[i].Value))
+		fields[i].Value = hex.EncodeToString(sum[:])[:hashValueLen]
+		fieldsHashedTotal.Inc()
+	}
+	return fields
+}
+
+// jsonExtractTransformer promotes nested JSON keys from the configured source fields
+// into new top-level fields; it is configured per-request via
+// _extract_json_fields / VL-Extract-JSON-Fields in the form srcField:json.path:dstField[,...].
+type jsonExtractTransformer struct {
+	rules []jsonExtractRule
+}
+
+type jsonExtractRule struct {
+	srcField string
+	path     []string
+	dstField string
+}
+
+func newJSONExtractTransformer(spec string) *jsonExtractTransformer {
+	if spec == "" {
+		return nil
+	}
+	jt := &jsonExtractTransformer{}
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			logger.Warnf("skipping malformed rule %q in _extract_json_fields; expected format is srcField:json.path:dstField", item)
+			continue
+		}
+		jt.rules = append(jt.rules, jsonExtractRule{
+			srcField: parts[0],
+			path:     strings.Split(parts[1], "."),
+			dstField: parts[2],
+		})
+	}
+	return jt
+}
+
+func (jt *jsonExtractTransformer) Transform(fields []logstorage.Field) []logstorage.Field {
+	for _, rule := range jt.rules {
+		for _, f := range fields {
+			if f.Name != rule.srcField {
+				continue
+			}
+			var v any
+			if err := json.Unmarshal([]byte(f.Value), &v); err != nil {
+				break
+			}
+			extracted, ok := lookupJSONPath(v, rule.path)
+			if !ok {
+				break
+			}
+			fields = append(fields, logstorage.Field{
+				Name:  rule.dstField,
+				Value: jsonValueToString(extracted),
+			})
+			fieldsExtractedTotal.Inc()
+			break
+		}
+	}
+	return fields
+}
+
+func lookupJSONPath(v any, path []string) (any, bool) {
+	cur := v
+	for _, key := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func jsonValueToString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+var (
+	fieldsRedactedTotal  = metrics.NewCounter(`vl_fields_transformed_total{action="redacted"}`)
+	fieldsDroppedTotal   = metrics.NewCounter(`vl_fields_transformed_total{action="dropped"}`)
+	fieldsHashedTotal    = metrics.NewCounter(`vl_fields_transformed_total{action="hashed"}`)
+	fieldsExtractedTotal = metrics.NewCounter(`vl_fields_transformed_total{action="extracted"}`)
+)
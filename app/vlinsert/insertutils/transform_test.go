@@ -0,0 +1,158 @@
+package insertutils
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestRedactTransformer(t *testing.T) {
+	rt := &redactTransformer{
+		rules: []redactRule{
+			{field: "password", re: regexp.MustCompile(`.+`)},
+			{field: "email", re: regexp.MustCompile(`[^@]+`)},
+		},
+	}
+
+	fields := []logstorage.Field{
+		{Name: "password", Value: "hunter2"},
+		{Name: "email", Value: "alice@example.com"},
+		{Name: "msg", Value: "hello"},
+	}
+	got := rt.Transform(fields)
+	want := []logstorage.Field{
+		{Name: "password", Value: "[REDACTED]"},
+		{Name: "email", Value: "[REDACTED]@example.com"},
+		{Name: "msg", Value: "hello"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected result\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestNewRenameDropTransformer(t *testing.T) {
+	if rt := newRenameDropTransformer("", ""); rt != nil {
+		t.Fatalf("expected nil transformer for empty rename and drop specs")
+	}
+}
+
+func TestRenameDropTransformer(t *testing.T) {
+	rt := newRenameDropTransformer("old:new", "secret")
+	fields := []logstorage.Field{
+		{Name: "old", Value: "1"},
+		{Name: "secret", Value: "2"},
+		{Name: "keep", Value: "3"},
+	}
+	got := rt.Transform(fields)
+	want := []logstorage.Field{
+		{Name: "new", Value: "1"},
+		{Name: "keep", Value: "3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected result\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestNewHashTransformer(t *testing.T) {
+	if ht := newHashTransformer(""); ht != nil {
+		t.Fatalf("expected nil transformer for empty spec")
+	}
+}
+
+func TestHashTransformer(t *testing.T) {
+	ht := newHashTransformer("user_id")
+	fields := []logstorage.Field{
+		{Name: "user_id", Value: "12345"},
+		{Name: "msg", Value: "hello"},
+	}
+	got := ht.Transform(fields)
+
+	if got[0].Name != "user_id" {
+		t.Fatalf("unexpected field name; got %q", got[0].Name)
+	}
+	if len(got[0].Value) != hashValueLen {
+		t.Fatalf("unexpected hash length; got %d; want %d", len(got[0].Value), hashValueLen)
+	}
+	if got[0].Value == "12345" {
+		t.Fatalf("value wasn't hashed")
+	}
+	if got[1].Value != "hello" {
+		t.Fatalf("unrelated field was modified: %v", got[1])
+	}
+
+	// Hashing must be deterministic for the same input.
+	again := ht.Transform([]logstorage.Field{{Name: "user_id", Value: "12345"}})
+	if again[0].Value != got[0].Value {
+		t.Fatalf("hash isn't deterministic: %q != %q", again[0].Value, got[0].Value)
+	}
+}
+
+func TestNewJSONExtractTransformer(t *testing.T) {
+	if jt := newJSONExtractTransformer(""); jt != nil {
+		t.Fatalf("expected nil transformer for empty spec")
+	}
+}
+
+func TestJSONExtractTransformer(t *testing.T) {
+	jt := newJSONExtractTransformer("data:user.name:user_name")
+	fields := []logstorage.Field{
+		{Name: "data", Value: `{"user":{"name":"alice","age":30}}`},
+	}
+	got := jt.Transform(fields)
+	if len(got) != 2 {
+		t.Fatalf("unexpected number of fields; got %d; want 2", len(got))
+	}
+	if got[1].Name != "user_name" || got[1].Value != "alice" {
+		t.Fatalf("unexpected extracted field: %+v", got[1])
+	}
+}
+
+func TestJSONExtractTransformerMissingPath(t *testing.T) {
+	jt := newJSONExtractTransformer("data:user.name:user_name")
+	fields := []logstorage.Field{
+		{Name: "data", Value: `{"user":{"other":"bob"}}`},
+	}
+	got := jt.Transform(fields)
+	if len(got) != 1 {
+		t.Fatalf("expected no field to be extracted when the path is missing; got %v", got)
+	}
+}
+
+func TestJSONExtractTransformerInvalidJSON(t *testing.T) {
+	jt := newJSONExtractTransformer("data:user.name:user_name")
+	fields := []logstorage.Field{
+		{Name: "data", Value: `not json`},
+	}
+	got := jt.Transform(fields)
+	if len(got) != 1 {
+		t.Fatalf("expected no field to be extracted for invalid JSON; got %v", got)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	v := map[string]any{
+		"a": map[string]any{
+			"b": "c",
+		},
+	}
+	if got, ok := lookupJSONPath(v, []string{"a", "b"}); !ok || got != "c" {
+		t.Fatalf("unexpected result: got=%v, ok=%v", got, ok)
+	}
+	if _, ok := lookupJSONPath(v, []string{"a", "missing"}); ok {
+		t.Fatalf("expected lookup to fail for a missing key")
+	}
+	if _, ok := lookupJSONPath(v, []string{"a", "b", "c"}); ok {
+		t.Fatalf("expected lookup to fail when descending into a non-object")
+	}
+}
+
+func TestJSONValueToString(t *testing.T) {
+	if s := jsonValueToString("foo"); s != "foo" {
+		t.Fatalf("unexpected result for string value: %q", s)
+	}
+	if s := jsonValueToString(float64(42)); s != "42" {
+		t.Fatalf("unexpected result for numeric value: %q", s)
+	}
+}
@@ -0,0 +1,168 @@
+// Package vlslog provides a log/slog.Handler, which writes log records into VictoriaLogs.
+package vlslog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vlinsert/insertutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// Options customize the log ingestion parameters used by a Handler.
+type Options struct {
+	// TenantID is the tenant the log entries are written to.
+	TenantID logstorage.TenantID
+
+	// StreamFields is the list of field names, which identify a log stream; see
+	// https://docs.victoriametrics.com/victorialogs/keyconcepts/#stream-fields
+	StreamFields []string
+
+	// Addr is the base URL of a remote VictoriaLogs instance, e.g. "http://localhost:9428".
+	//
+	// If Addr is empty, log records are written in-process via insertutils.CommonParams.NewLogMessageProcessor().
+	Addr string
+}
+
+// Handler is a log/slog.Handler, which translates slog.Record values into
+// []logstorage.Field and forwards them into VictoriaLogs, either in-process
+// or over the JSON stream ingestion HTTP API; see NewHandler.
+type Handler struct {
+	lmp     insertutils.LogMessageProcessor
+	gcpSink *insertutils.GCPLogSink
+	remote  *remoteClient
+
+	groups []string
+	attrs  []logstorage.Field
+}
+
+// NewHandler returns a new Handler built according to opts.
+//
+// MustClose must be called on the returned Handler when it is no longer needed.
+func NewHandler(opts Options) *Handler {
+	h := &Handler{}
+	if opts.Addr != "" {
+		h.remote = newRemoteClient(opts)
+		return h
+	}
+	cp := &insertutils.CommonParams{
+		TenantID:     opts.TenantID,
+		TimeField:    "_time",
+		StreamFields: opts.StreamFields,
+	}
+	var extraSinks []insertutils.LogSink
+	gcpSink, err := insertutils.NewGCPLogSinkFromFlags()
+	if err != nil {
+		logger.Fatalf("cannot create Google Cloud Logging sink: %s", err)
+	}
+	if gcpSink != nil {
+		h.gcpSink = gcpSink
+		extraSinks = append(extraSinks, gcpSink)
+	}
+	h.lmp = cp.NewLogMessageProcessor(extraSinks...)
+	return h
+}
+
+// Enabled implements log/slog.Handler.
+func (h *Handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements log/slog.Handler.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]logstorage.Field, 0, len(h.attrs)+r.NumAttrs()+2)
+	fields = append(fields, h.attrs...)
+	fields = append(fields, logstorage.Field{
+		Name:  "_msg",
+		Value: r.Message,
+	})
+	fields = append(fields, logstorage.Field{
+		Name:  "level",
+		Value: levelString(r.Level),
+	})
+
+	prefix := strings.Join(h.groups, ".")
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, prefix, a)
+		return true
+	})
+
+	timestamp := r.Time.UnixNano()
+	if h.remote != nil {
+		return h.remote.send(timestamp, fields)
+	}
+	h.lmp.AddRow(timestamp, fields)
+	return nil
+}
+
+// WithAttrs implements log/slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	prefix := strings.Join(h.groups, ".")
+	nh.attrs = append(append([]logstorage.Field{}, h.attrs...), attrsToFields(prefix, attrs)...)
+	return &nh
+}
+
+// WithGroup implements log/slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	nh := *h
+	nh.groups = append(append([]string{}, h.groups...), name)
+	return &nh
+}
+
+// MustClose flushes the remaining log records and releases resources occupied by h.
+func (h *Handler) MustClose() {
+	if h.remote != nil {
+		h.remote.mustClose()
+		return
+	}
+	h.lmp.MustClose()
+	if h.gcpSink != nil {
+		h.gcpSink.MustClose()
+	}
+}
+
+func levelString(lvl slog.Level) string {
+	switch {
+	case lvl < slog.LevelInfo:
+		return "DEBUG"
+	case lvl < slog.LevelWarn:
+		return "INFO"
+	case lvl < slog.LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+func attrsToFields(prefix string, attrs []slog.Attr) []logstorage.Field {
+	var fields []logstorage.Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, prefix, a)
+	}
+	return fields
+}
+
+// appendAttr flattens a, nesting slog.Group values into dotted field names.
+func appendAttr(fields []logstorage.Field, prefix string, a slog.Attr) []logstorage.Field {
+	a.Value = a.Value.Resolve()
+	name := a.Key
+	if prefix != "" {
+		name = prefix + "." + a.Key
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, name, ga)
+		}
+		return fields
+	}
+	return append(fields, logstorage.Field{
+		Name:  name,
+		Value: a.Value.String(),
+	})
+}
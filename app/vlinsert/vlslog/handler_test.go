@@ -0,0 +1,65 @@
+package vlslog
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+func TestLevelString(t *testing.T) {
+	f := func(lvl slog.Level, want string) {
+		t.Helper()
+		if got := levelString(lvl); got != want {
+			t.Fatalf("unexpected result for level=%v; got %q; want %q", lvl, got, want)
+		}
+	}
+
+	f(slog.LevelDebug, "DEBUG")
+	f(slog.LevelInfo, "INFO")
+	f(slog.LevelWarn, "WARN")
+	f(slog.LevelError, "ERROR")
+	f(slog.Level(100), "ERROR")
+}
+
+func TestAppendAttr(t *testing.T) {
+	f := func(prefix string, a slog.Attr, want []logstorage.Field) {
+		t.Helper()
+		got := appendAttr(nil, prefix, a)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected result\ngot:  %v\nwant: %v", got, want)
+		}
+	}
+
+	f("", slog.String("foo", "bar"), []logstorage.Field{
+		{Name: "foo", Value: "bar"},
+	})
+
+	f("req", slog.Int("status", 200), []logstorage.Field{
+		{Name: "req.status", Value: "200"},
+	})
+
+	f("", slog.Group("user", slog.String("name", "alice"), slog.Int("id", 1)), []logstorage.Field{
+		{Name: "user.name", Value: "alice"},
+		{Name: "user.id", Value: "1"},
+	})
+
+	f("req", slog.Group("user", slog.String("name", "alice")), []logstorage.Field{
+		{Name: "req.user.name", Value: "alice"},
+	})
+}
+
+func TestAttrsToFields(t *testing.T) {
+	got := attrsToFields("", []slog.Attr{
+		slog.String("foo", "bar"),
+		slog.Group("user", slog.String("name", "alice")),
+	})
+	want := []logstorage.Field{
+		{Name: "foo", Value: "bar"},
+		{Name: "user.name", Value: "alice"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected result\ngot:  %v\nwant: %v", got, want)
+	}
+}
@@ -0,0 +1,196 @@
+package vlslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
+)
+
+// remoteFlushInterval is the base interval, with a small random jitter added, at
+// which the background goroutine checks whether buffered log records are due for
+// shipping to the remote VictoriaLogs instance; a tick that comes less than one
+// interval after the previous flush is skipped, so records can wait up to about
+// two intervals.
+const remoteFlushInterval = time.Second
+
+// remoteRequestTimeout bounds a single flush's HTTP round-trip, so that a
+// stalled or unreachable remote endpoint can't block the flush goroutine forever.
+const remoteRequestTimeout = 5 * time.Second
+
+// remoteMaxBufferedRows caps the number of log records buffered in memory
+// awaiting flush; rows arriving once the buffer is full are dropped instead
+// of piling up unboundedly against a slow or unreachable remote endpoint.
+const remoteMaxBufferedRows = 10000
+
+// remoteClient buffers log records and ships them in batches to a remote
+// VictoriaLogs instance over its JSON stream ingestion endpoint; see
+// https://docs.victoriametrics.com/victorialogs/data-ingestion/#how-to-send-json-logs
+//
+// Records are appended to an in-memory buffer by send() and flushed from a
+// background goroutine, so a caller of slog.Logger.Info/Error/... never blocks
+// on network I/O.
+type remoteClient struct {
+	url          string
+	accountID    string
+	projectID    string
+	streamFields string
+
+	client *http.Client
+
+	mu            sync.Mutex
+	wg            sync.WaitGroup
+	stopCh        chan struct{}
+	buf           bytes.Buffer
+	bufRows       int
+	lastFlushTime time.Time
+}
+
+func newRemoteClient(opts Options) *remoteClient {
+	rc := &remoteClient{
+		url:          strings.TrimSuffix(opts.Addr, "/") + "/insert/jsonline",
+		accountID:    strconv.FormatUint(uint64(opts.TenantID.AccountID), 10),
+		projectID:    strconv.FormatUint(uint64(opts.TenantID.ProjectID), 10),
+		streamFields: strings.Join(opts.StreamFields, ","),
+		client:       &http.Client{Timeout: remoteRequestTimeout},
+		stopCh:       make(chan struct{}),
+	}
+	rc.initPeriodicFlush()
+	return rc
+}
+
+func (rc *remoteClient) initPeriodicFlush() {
+	rc.mu.Lock()
+	rc.lastFlushTime = time.Now()
+	rc.mu.Unlock()
+
+	rc.wg.Add(1)
+	go func() {
+		defer rc.wg.Done()
+
+		d := timeutil.AddJitterToDuration(remoteFlushInterval)
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-rc.stopCh:
+				return
+			case <-ticker.C:
+				rc.mu.Lock()
+				var data []byte
+				var rows int
+				if time.Since(rc.lastFlushTime) >= d {
+					data, rows = rc.swapBufferLocked()
+				}
+				rc.mu.Unlock()
+				rc.flushRows(data, rows)
+			}
+		}
+	}()
+}
+
+// send appends the given log record to rc's buffer for asynchronous delivery.
+//
+// send never performs network I/O itself, so it cannot block the calling goroutine.
+func (rc *remoteClient) send(timestamp int64, fields []logstorage.Field) error {
+	m := make(map[string]string, len(fields)+1)
+	for _, f := range fields {
+		m[f.Name] = f.Value
+	}
+	m["_time"] = time.Unix(0, timestamp).UTC().Format(time.RFC3339Nano)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("cannot marshal log record: %w", err)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.bufRows >= remoteMaxBufferedRows {
+		rowsDroppedTotalRemoteBufferFull.Inc()
+		logger.Warnf("dropping log record destined for %q because the buffer of %d pending records is full", rc.url, remoteMaxBufferedRows)
+		return nil
+	}
+
+	rc.buf.Write(data)
+	rc.buf.WriteByte('\n')
+	rc.bufRows++
+	return nil
+}
+
+// swapBufferLocked detaches the currently buffered records and resets the buffer,
+// so the caller can ship them to the remote endpoint without holding rc.mu.
+//
+// rc.mu must be held by the caller.
+func (rc *remoteClient) swapBufferLocked() (data []byte, rows int) {
+	rc.lastFlushTime = time.Now()
+	if rc.bufRows == 0 {
+		return nil, 0
+	}
+
+	data = append([]byte(nil), rc.buf.Bytes()...)
+	rows = rc.bufRows
+	rc.buf.Reset()
+	rc.bufRows = 0
+	return data, rows
+}
+
+// flushRows sends the given previously-buffered records to the remote endpoint.
+//
+// It performs network I/O and must be called without rc.mu held.
+func (rc *remoteClient) flushRows(data []byte, rows int) {
+	if rows == 0 {
+		return
+	}
+	if err := rc.flush(data); err != nil {
+		logger.Warnf("cannot send %d buffered log record(s) to %q: %s", rows, rc.url, err)
+	}
+}
+
+func (rc *remoteClient) flush(data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, rc.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot create request to %q: %w", rc.url, err)
+	}
+	req.Header.Set("Content-Type", "application/stream+json")
+	req.Header.Set("AccountID", rc.accountID)
+	req.Header.Set("ProjectID", rc.projectID)
+	if rc.streamFields != "" {
+		req.Header.Set("VL-Stream-Fields", rc.streamFields)
+	}
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send log records to %q: %w", rc.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status code returned from %q: %d", rc.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// mustClose flushes the remaining buffered records and stops the background flush goroutine.
+func (rc *remoteClient) mustClose() {
+	close(rc.stopCh)
+	rc.wg.Wait()
+
+	rc.mu.Lock()
+	data, rows := rc.swapBufferLocked()
+	rc.mu.Unlock()
+	rc.flushRows(data, rows)
+}
+
+var rowsDroppedTotalRemoteBufferFull = metrics.NewCounter(`vl_rows_dropped_total{reason="remote_buffer_full"}`)
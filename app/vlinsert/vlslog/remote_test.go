@@ -0,0 +1,160 @@
+package vlslog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logstorage"
+)
+
+// testServer captures the bodies of every request it receives.
+type testServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+func newTestServer() *testServer {
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		ts.mu.Lock()
+		ts.bodies = append(ts.bodies, body)
+		ts.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return ts
+}
+
+func (ts *testServer) requestCount() int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return len(ts.bodies)
+}
+
+func (ts *testServer) lastBody() []byte {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(ts.bodies) == 0 {
+		return nil
+	}
+	return ts.bodies[len(ts.bodies)-1]
+}
+
+func TestRemoteClientSendDoesNotPerformNetworkIO(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	rc := newRemoteClient(Options{Addr: ts.URL})
+	defer rc.mustClose()
+
+	for i := 0; i < 3; i++ {
+		if err := rc.send(int64(i), []logstorage.Field{{Name: "_msg", Value: "hello"}}); err != nil {
+			t.Fatalf("unexpected error from send: %s", err)
+		}
+	}
+
+	if n := ts.requestCount(); n != 0 {
+		t.Fatalf("send must not perform network I/O; got %d requests", n)
+	}
+
+	rc.mu.Lock()
+	bufRows := rc.bufRows
+	rc.mu.Unlock()
+	if bufRows != 3 {
+		t.Fatalf("unexpected number of buffered rows; got %d; want 3", bufRows)
+	}
+}
+
+func TestRemoteClientMustCloseFlushesBufferedRows(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	rc := newRemoteClient(Options{Addr: ts.URL})
+
+	for i := 0; i < 5; i++ {
+		if err := rc.send(int64(i), []logstorage.Field{{Name: "_msg", Value: "hello"}}); err != nil {
+			t.Fatalf("unexpected error from send: %s", err)
+		}
+	}
+
+	rc.mustClose()
+
+	if n := ts.requestCount(); n != 1 {
+		t.Fatalf("unexpected number of flush requests; got %d; want 1", n)
+	}
+	lines := bytes.Count(ts.lastBody(), []byte("\n"))
+	if lines != 5 {
+		t.Fatalf("unexpected number of buffered rows shipped in the flush; got %d; want 5", lines)
+	}
+}
+
+func TestRemoteClientDropsRowsOnceBufferIsFull(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	rc := newRemoteClient(Options{Addr: ts.URL})
+
+	for i := 0; i < remoteMaxBufferedRows+5; i++ {
+		if err := rc.send(int64(i), []logstorage.Field{{Name: "_msg", Value: "hello"}}); err != nil {
+			t.Fatalf("unexpected error from send: %s", err)
+		}
+	}
+
+	rc.mu.Lock()
+	bufRows := rc.bufRows
+	rc.mu.Unlock()
+	if bufRows != remoteMaxBufferedRows {
+		t.Fatalf("unexpected number of buffered rows; got %d; want %d", bufRows, remoteMaxBufferedRows)
+	}
+
+	rc.mustClose()
+
+	lines := bytes.Count(ts.lastBody(), []byte("\n"))
+	if lines != remoteMaxBufferedRows {
+		t.Fatalf("unexpected number of rows shipped; got %d; want %d", lines, remoteMaxBufferedRows)
+	}
+}
+
+func TestRemoteClientFlushSetsExpectedHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	rc := newRemoteClient(Options{
+		Addr:         ts.URL,
+		TenantID:     logstorage.TenantID{AccountID: 1, ProjectID: 2},
+		StreamFields: []string{"host", "app"},
+	})
+
+	if err := rc.send(0, []logstorage.Field{{Name: "_msg", Value: "hello"}}); err != nil {
+		t.Fatalf("unexpected error from send: %s", err)
+	}
+	rc.mustClose()
+
+	if got := gotHeaders.Get("AccountID"); got != "1" {
+		t.Fatalf("unexpected AccountID header: %q", got)
+	}
+	if got := gotHeaders.Get("ProjectID"); got != "2" {
+		t.Fatalf("unexpected ProjectID header: %q", got)
+	}
+	if got := gotHeaders.Get("VL-Stream-Fields"); got != "host,app" {
+		t.Fatalf("unexpected VL-Stream-Fields header: %q", got)
+	}
+	if got := gotHeaders.Get("Content-Type"); !strings.Contains(got, "stream+json") {
+		t.Fatalf("unexpected Content-Type header: %q", got)
+	}
+}